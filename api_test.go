@@ -20,6 +20,9 @@ var (
 	SetCountRequest           = func(length int) *http.Request {
 		return httptest.NewRequest("GET", fmt.Sprintf("/?offset=0&count=%d", length), nil)
 	}
+	SetOffsetCountRequest = func(offset, count int) *http.Request {
+		return httptest.NewRequest("GET", fmt.Sprintf("/?offset=%d&count=%d", offset, count), nil)
+	}
 )
 
 func runRequest(t *testing.T, srv http.Handler, r *http.Request) (content []*ContentItem) {
@@ -62,8 +65,7 @@ func TestOffsetResponseOrder(t *testing.T) {
 	assert.Len(t, content, 5)
 
 	for i, item := range content {
-		// add offset of 5 to i
-		assert.Equal(t, Provider(item.Source), DefaultConfig[i+5%len(DefaultConfig)].Type)
+		assert.Equal(t, DefaultConfig.providerAt(5, i), Provider(item.Source))
 	}
 }
 
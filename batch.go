@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Default limits applied when App.MaxBatchItems / App.MaxBatchTotalCount are
+// left at their zero value.
+const (
+	DefaultMaxBatchItems      = 100
+	DefaultMaxBatchTotalCount = 10000
+)
+
+// BatchQuery is one request within a POST /batch call.
+type BatchQuery struct {
+	ID     string `json:"id"`
+	Offset int    `json:"offset"`
+	Count  int    `json:"count"`
+}
+
+// BatchResult is one response within a POST /batch call, matching the ID of
+// the BatchQuery it answers.
+type BatchResult struct {
+	ID      string         `json:"id"`
+	Content []*ContentItem `json:"content,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func (a *App) maxBatchItems() int {
+	if a.MaxBatchItems > 0 {
+		return a.MaxBatchItems
+	}
+	return DefaultMaxBatchItems
+}
+
+func (a *App) maxBatchTotalCount() int {
+	if a.MaxBatchTotalCount > 0 {
+		return a.MaxBatchTotalCount
+	}
+	return DefaultMaxBatchTotalCount
+}
+
+// batchSpan is the half-open item range [start, end) a BatchQuery resolves
+// to, in absolute offset terms.
+type batchSpan struct {
+	start, end int
+}
+
+// windowFetch holds the in-progress/completed result of fetching one merged
+// span, shared by every query in the batch whose own span falls inside it.
+type windowFetch struct {
+	content []*ContentItem
+	err     error
+}
+
+// mergeSpans sorts spans by start and merges every pair that overlaps or
+// touches (span.end == next.start) into a single covering span, so that
+// queries with overlapping offset/count windows share one underlying fetch
+// instead of repeating work for the overlapping portion.
+func mergeSpans(spans []batchSpan) []batchSpan {
+	sorted := make([]batchSpan, len(spans))
+	copy(sorted, spans)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	merged := sorted[:0:0]
+	for _, s := range sorted {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			if s.end > merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// BatchHandler serves POST /batch: clients submit an array of
+// {id, offset, count} queries and get back a corresponding array of
+// {id, content, error} results, executed concurrently. Queries whose
+// offset/count windows overlap share the same underlying fetch: each
+// query's span is merged with every other overlapping span into a minimal
+// covering set, each covering span is fetched once, and every query then
+// slices its own sub-range out of the span that covers it.
+func (a *App) BatchHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var queries []BatchQuery
+	if err := json.NewDecoder(req.Body).Decode(&queries); err != nil {
+		http.Error(w, "invalid batch request body", http.StatusBadRequest)
+		return
+	}
+
+	// clamp negative offset/count the same way getQueryParameters does for the
+	// GET path, so a malformed query can't index fetchRange's channels/Config
+	// out of range or make it run unbounded
+	for i, q := range queries {
+		if q.Offset < 0 {
+			queries[i].Offset = 0
+		}
+		if q.Count < 0 {
+			queries[i].Count = 0
+		}
+	}
+
+	if len(queries) > a.maxBatchItems() {
+		offender := queries[a.maxBatchItems()]
+		writeBatchResults(w, []BatchResult{{ID: offender.ID, Error: "batch exceeds max items"}})
+		return
+	}
+	total := 0
+	for _, q := range queries {
+		total += q.Count
+		if total > a.maxBatchTotalCount() {
+			writeBatchResults(w, []BatchResult{{ID: q.ID, Error: "batch exceeds max total count"}})
+			return
+		}
+	}
+
+	// merge overlapping offset/count windows into a minimal covering set of
+	// spans, so overlapping ranges share one set of provider fetches instead
+	// of repeating the overlapping portion
+	spans := make([]batchSpan, len(queries))
+	for i, q := range queries {
+		spans[i] = batchSpan{start: q.Offset, end: q.Offset + q.Count}
+	}
+	merged := mergeSpans(spans)
+	fetches := make([]*windowFetch, len(merged))
+
+	opts := fetchOptionsFor(req)
+	var wg sync.WaitGroup
+	for i, span := range merged {
+		fetches[i] = &windowFetch{}
+		wg.Add(1)
+		go func(span batchSpan, fetch *windowFetch) {
+			defer wg.Done()
+			fetch.err = a.fetchRange(req.Context(), span.start, span.end-span.start, opts, func(item *ContentItem) error {
+				fetch.content = append(fetch.content, item)
+				return nil
+			})
+		}(span, fetches[i])
+	}
+	wg.Wait()
+
+	results := make([]BatchResult, len(queries))
+	for i, q := range queries {
+		// merged is sorted by start and non-overlapping, so the span covering
+		// q is the last one whose start is <= q.Offset
+		spanIdx := sort.Search(len(merged), func(j int) bool { return merged[j].start > q.Offset }) - 1
+		span, fetch := merged[spanIdx], fetches[spanIdx]
+
+		res := BatchResult{ID: q.ID}
+		from := q.Offset - span.start
+		to := from + q.Count
+		if from < len(fetch.content) {
+			end := to
+			if end > len(fetch.content) {
+				end = len(fetch.content)
+			}
+			res.Content = fetch.content[from:end]
+		}
+		// a span-level error only belongs to this query if it actually cut off
+		// data this query needed; queries whose own sub-range was already
+		// fully satisfied before the failure happened later in the span
+		// should come back with their complete content and no error
+		if fetch.err != nil && to > len(fetch.content) {
+			res.Error = fetch.err.Error()
+		}
+		results[i] = res
+	}
+
+	writeBatchResults(w, results)
+}
+
+func writeBatchResults(w http.ResponseWriter, results []BatchResult) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runBatch(t *testing.T, queries []BatchQuery) []BatchResult {
+	body, err := json.Marshal(queries)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	app.BatchHandler(w, r)
+
+	var results []BatchResult
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+	return results
+}
+
+func TestBatchBasic(t *testing.T) {
+	results := runBatch(t, []BatchQuery{
+		{ID: "a", Offset: 0, Count: 3},
+		{ID: "b", Offset: 1, Count: 2},
+	})
+
+	assert.Len(t, results, 2)
+	for _, res := range results {
+		assert.Empty(t, res.Error)
+	}
+
+	assert.Equal(t, "a", results[0].ID)
+	assert.Len(t, results[0].Content, 3)
+	for i, item := range results[0].Content {
+		assert.Equal(t, DefaultConfig.providerAt(0, i), Provider(item.Source))
+	}
+
+	assert.Equal(t, "b", results[1].ID)
+	assert.Len(t, results[1].Content, 2)
+	for i, item := range results[1].Content {
+		assert.Equal(t, DefaultConfig.providerAt(1, i), Provider(item.Source))
+	}
+}
+
+// TestBatchOverlappingWindows asserts overlapping queries each still get the
+// exact sub-range their own offset/count describes, even though they share
+// an underlying fetch.
+func TestBatchOverlappingWindows(t *testing.T) {
+	results := runBatch(t, []BatchQuery{
+		{ID: "wide", Offset: 0, Count: 10},
+		{ID: "narrow", Offset: 5, Count: 3},
+	})
+
+	byID := map[string]BatchResult{}
+	for _, res := range results {
+		byID[res.ID] = res
+	}
+
+	assert.Len(t, byID["wide"].Content, 10)
+	assert.Len(t, byID["narrow"].Content, 3)
+	for i, item := range byID["narrow"].Content {
+		assert.Equal(t, DefaultConfig.providerAt(5, i), Provider(item.Source))
+		// the narrow window is a sub-range of the wide one, so it must match
+		// the wide result at the same absolute offset
+		assert.Equal(t, byID["wide"].Content[5+i].Source, item.Source)
+	}
+}
+
+func TestBatchExceedsMaxItems(t *testing.T) {
+	a := &App{
+		ContentClients: app.ContentClients,
+		Config:         app.Config,
+		MaxBatchItems:  1,
+	}
+	body, err := json.Marshal([]BatchQuery{
+		{ID: "a", Offset: 0, Count: 1},
+		{ID: "b", Offset: 0, Count: 1},
+	})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	a.BatchHandler(w, r)
+
+	var results []BatchResult
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+	assert.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+func TestBatchExceedsMaxTotalCount(t *testing.T) {
+	a := &App{
+		ContentClients:     app.ContentClients,
+		Config:             app.Config,
+		MaxBatchTotalCount: 5,
+	}
+	body, err := json.Marshal([]BatchQuery{{ID: "a", Offset: 0, Count: 10}})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	a.BatchHandler(w, r)
+
+	var results []BatchResult
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+	assert.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+// TestBatchNegativeOffsetCount asserts negative offset/count are clamped to
+// zero rather than panicking or running unbounded.
+func TestBatchNegativeOffsetCount(t *testing.T) {
+	results := runBatch(t, []BatchQuery{{ID: "a", Offset: -5, Count: -1}})
+
+	assert.Len(t, results, 1)
+	assert.Empty(t, results[0].Error)
+	assert.Len(t, results[0].Content, 0)
+}
+
+func TestBatchMethodNotAllowed(t *testing.T) {
+	r := httptest.NewRequest("GET", "/batch", nil)
+	w := httptest.NewRecorder()
+	app.BatchHandler(w, r)
+	assert.Equal(t, 405, w.Code)
+}
+
+func TestBatchInvalidBody(t *testing.T) {
+	r := httptest.NewRequest("POST", "/batch", bytes.NewReader([]byte("not json")))
+	w := httptest.NewRecorder()
+	app.BatchHandler(w, r)
+	assert.Equal(t, 400, w.Code)
+}
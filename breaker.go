@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff parameters shared by every provider's circuit breaker: base is the
+// delay after the first failure, doubling (factor 2) up to max, with jitter
+// added so concurrent requests to the same provider don't all retry in lockstep.
+const (
+	backoffBase      = 100 * time.Millisecond
+	backoffMax       = 2 * time.Second
+	breakerWindow    = 30 * time.Second
+	breakerThreshold = 5
+)
+
+// providerStats tracks recent failures for one provider so a flapping
+// upstream doesn't make every request pay its full timeout: once failures
+// reach breakerThreshold within breakerWindow, the circuit opens and calls
+// short-circuit straight to the fallback until backoffUntil elapses.
+type providerStats struct {
+	mu           sync.Mutex
+	consecutive  int
+	windowStart  time.Time
+	backoffUntil time.Time
+}
+
+// recordFailure notes a failed call and extends the backoff window
+// exponentially, resetting the failure count if the prior window has expired.
+func (s *providerStats) recordFailure() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.windowStart.IsZero() || now.Sub(s.windowStart) > breakerWindow {
+		s.windowStart = now
+		s.consecutive = 0
+	}
+	s.consecutive++
+
+	shift := s.consecutive - 1
+	if shift > 5 {
+		shift = 5 // caps 100ms*2^5 = 3.2s ahead of the backoffMax clamp below
+	}
+	backoff := backoffBase * time.Duration(int64(1)<<uint(shift))
+	if backoff > backoffMax {
+		backoff = backoffMax
+	}
+	backoff += time.Duration(rand.Int63n(int64(backoff)/4 + 1))
+	s.backoffUntil = now.Add(backoff)
+}
+
+// recordSuccess clears the failure streak, closing the circuit.
+func (s *providerStats) recordSuccess() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consecutive = 0
+	s.backoffUntil = time.Time{}
+}
+
+// circuitOpen reports whether this provider has failed often enough recently
+// that callers should skip straight to the fallback rather than pay the full
+// timeout again.
+func (s *providerStats) circuitOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.consecutive >= breakerThreshold && time.Now().Before(s.backoffUntil)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCircuitOpensAfterThreshold asserts the circuit stays closed below
+// breakerThreshold failures and opens once it's reached.
+func TestCircuitOpensAfterThreshold(t *testing.T) {
+	s := &providerStats{}
+
+	for i := 0; i < breakerThreshold-1; i++ {
+		s.recordFailure()
+		assert.False(t, s.circuitOpen(), "circuit should stay closed before reaching the threshold")
+	}
+	s.recordFailure()
+	assert.True(t, s.circuitOpen(), "circuit should open once the threshold is reached")
+}
+
+// TestRecordSuccessClosesCircuit asserts a success clears the failure streak
+// and closes an open circuit.
+func TestRecordSuccessClosesCircuit(t *testing.T) {
+	s := &providerStats{}
+	for i := 0; i < breakerThreshold; i++ {
+		s.recordFailure()
+	}
+	assert.True(t, s.circuitOpen())
+
+	s.recordSuccess()
+	assert.False(t, s.circuitOpen())
+}
+
+// TestRecordFailureBacksOff asserts each failure sets backoffUntil to a
+// future time bounded by backoffMax (plus its jitter term).
+func TestRecordFailureBacksOff(t *testing.T) {
+	s := &providerStats{}
+	before := time.Now()
+	s.recordFailure()
+
+	assert.True(t, s.backoffUntil.After(before))
+	assert.True(t, s.backoffUntil.Before(before.Add(backoffMax+backoffMax/4+time.Second)))
+}
+
+// TestRecordFailureResetsAfterWindow asserts the consecutive-failure count
+// resets once breakerWindow has elapsed since the first failure in a streak,
+// so an open circuit doesn't stay open forever off of stale failures.
+func TestRecordFailureResetsAfterWindow(t *testing.T) {
+	s := &providerStats{}
+	for i := 0; i < breakerThreshold; i++ {
+		s.recordFailure()
+	}
+	assert.True(t, s.circuitOpen())
+
+	s.windowStart = time.Now().Add(-breakerWindow - time.Second)
+	s.recordFailure()
+
+	assert.Equal(t, 1, s.consecutive)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache stores provider responses for a short TTL so that identical lookups
+// don't re-hit the upstream Client.
+type Cache interface {
+	Get(key string) ([]*ContentItem, bool)
+	Set(key string, items []*ContentItem, ttl time.Duration)
+	Delete(key string)
+}
+
+// DefaultCacheTTL is used when App.CacheTTL is left at its zero value.
+const DefaultCacheTTL = 30 * time.Second
+
+// DefaultCacheCapacity bounds an LRUCache created with NewLRUCache(0).
+const DefaultCacheCapacity = 10000
+
+type lruEntry struct {
+	key       string
+	items     []*ContentItem
+	expiresAt time.Time
+}
+
+// LRUCache is a size-bounded, in-process Cache with a per-entry TTL.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	index    map[string]*list.Element
+}
+
+// NewLRUCache creates an LRUCache holding at most capacity entries. A
+// non-positive capacity falls back to DefaultCacheCapacity.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheCapacity
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		index:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the cached items for key, or ok=false if there's no entry or
+// it has expired.
+func (c *LRUCache) Get(key string) ([]*ContentItem, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.ll.Remove(el)
+		delete(c.index, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return entry.items, true
+}
+
+// Set stores items under key for ttl, evicting the least recently used entry
+// if the cache is over capacity.
+func (c *LRUCache) Set(key string, items []*ContentItem, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.items = items
+		entry.expiresAt = time.Now().Add(ttl)
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, items: items, expiresAt: time.Now().Add(ttl)})
+	c.index[key] = el
+
+	if c.ll.Len() > c.capacity {
+		if oldest := c.ll.Back(); oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.index, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
+
+// Delete removes key from the cache, if present.
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.index[key]; ok {
+		c.ll.Remove(el)
+		delete(c.index, key)
+	}
+}
+
+// FetchOptions carries the per-request context a fetch needs to key and
+// possibly bypass the cache.
+type FetchOptions struct {
+	// UserIP is part of the cache key, alongside provider and query params.
+	UserIP string
+	// NoCache skips both reading and writing the cache, set from a
+	// "Cache-Control: no-cache" request header.
+	NoCache bool
+}
+
+// fetchOptionsFor builds the FetchOptions for an inbound request.
+func fetchOptionsFor(r *http.Request) FetchOptions {
+	return FetchOptions{
+		UserIP:  clientIP(r),
+		NoCache: bypassCache(r),
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bypassCache(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("Cache-Control")), "no-cache")
+}
+
+func cacheKey(provider Provider, opts FetchOptions, query string, count int) string {
+	return fmt.Sprintf("%s|%s|%s|%d", provider, opts.UserIP, query, count)
+}
+
+func (a *App) cache() Cache {
+	a.cacheOnce.Do(func() {
+		if a.Cache == nil {
+			a.Cache = NewLRUCache(0)
+		}
+	})
+	return a.Cache
+}
+
+func (a *App) cacheTTL() time.Duration {
+	if a.CacheTTL > 0 {
+		return a.CacheTTL
+	}
+	return DefaultCacheTTL
+}
+
+// CacheStats is a point-in-time snapshot of cache hit/miss counters.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+func (a *App) cacheStats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadInt64(&a.cacheHits),
+		Misses: atomic.LoadInt64(&a.cacheMisses),
+	}
+}
+
+// fetchWithCache fetches "Test"/1 content from provider, serving it out of
+// the cache when possible and coalescing concurrent cache misses for the
+// same key into a single upstream call via singleflight — this is what keeps
+// a stress test issuing a million identical requests from also issuing a
+// million redundant provider fetches.
+func (a *App) fetchWithCache(ctx context.Context, provider Provider, opts FetchOptions) ([]*ContentItem, error) {
+	key := cacheKey(provider, opts, "Test", 1)
+
+	if !opts.NoCache {
+		if items, ok := a.cache().Get(key); ok {
+			atomic.AddInt64(&a.cacheHits, 1)
+			return items, nil
+		}
+	}
+	atomic.AddInt64(&a.cacheMisses, 1)
+
+	stats := a.providerStats(provider)
+	v, err, _ := a.sfGroup.Do(key, func() (interface{}, error) {
+		if stats.circuitOpen() {
+			return nil, errCircuitOpen
+		}
+
+		fctx, cancel := context.WithTimeout(ctx, a.PoolConfig.providerTimeout())
+		defer cancel()
+
+		items, err := a.ContentClients[provider].GetContent(fctx, "Test", 1)
+		if err != nil {
+			stats.recordFailure()
+			return nil, err
+		}
+		stats.recordSuccess()
+		if !opts.NoCache {
+			a.cache().Set(key, items, a.cacheTTL())
+		}
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*ContentItem), nil
+}
+
+// CacheStatsHandler serves a JSON snapshot of the cache's hit/miss counters.
+// Intended to be mounted on an internal-only path, e.g. "/internal/cache".
+func (a *App) CacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.cacheStats())
+}
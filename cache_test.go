@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheGetSetDelete(t *testing.T) {
+	c := NewLRUCache(0)
+
+	_, ok := c.Get("missing")
+	assert.False(t, ok)
+
+	items := []*ContentItem{{Source: "reddit", Title: "hi"}}
+	c.Set("key", items, time.Minute)
+
+	got, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, items, got)
+
+	c.Delete("key")
+	_, ok = c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	c := NewLRUCache(0)
+	c.Set("key", []*ContentItem{{Source: "reddit"}}, time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		_, ok := c.Get("key")
+		return !ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestLRUCacheEvictsOldest(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", []*ContentItem{{Source: "a"}}, time.Minute)
+	c.Set("b", []*ContentItem{{Source: "b"}}, time.Minute)
+	c.Set("c", []*ContentItem{{Source: "c"}}, time.Minute)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "oldest entry should have been evicted")
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+}
+
+// countingClient counts how many times GetContent actually runs, to verify
+// fetchWithCache's caching and singleflight coalescing.
+type countingClient struct {
+	calls int64
+	delay time.Duration
+}
+
+func (c *countingClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	atomic.AddInt64(&c.calls, 1)
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return []*ContentItem{{Source: "counting", Title: query}}, nil
+}
+
+func TestFetchWithCacheHitsAndMisses(t *testing.T) {
+	client := &countingClient{}
+	a := &App{ContentClients: map[Provider]Client{"counting": client}}
+	opts := FetchOptions{UserIP: "1.2.3.4"}
+
+	_, err := a.fetchWithCache(context.Background(), "counting", opts)
+	assert.NoError(t, err)
+	_, err = a.fetchWithCache(context.Background(), "counting", opts)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 1, client.calls, "second call should be served from cache")
+	stats := a.cacheStats()
+	assert.EqualValues(t, 1, stats.Hits)
+	assert.EqualValues(t, 1, stats.Misses)
+}
+
+func TestFetchWithCacheBypass(t *testing.T) {
+	client := &countingClient{}
+	a := &App{ContentClients: map[Provider]Client{"counting": client}}
+	opts := FetchOptions{UserIP: "1.2.3.4", NoCache: true}
+
+	_, err := a.fetchWithCache(context.Background(), "counting", opts)
+	assert.NoError(t, err)
+	_, err = a.fetchWithCache(context.Background(), "counting", opts)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 2, client.calls, "no-cache should re-fetch every time")
+}
+
+// TestFetchWithCacheCoalescesConcurrentMisses asserts concurrent cache misses
+// for the same key are coalesced into a single upstream call via
+// singleflight, instead of each issuing its own provider fetch.
+func TestFetchWithCacheCoalescesConcurrentMisses(t *testing.T) {
+	client := &countingClient{delay: 50 * time.Millisecond}
+	a := &App{ContentClients: map[Provider]Client{"counting": client}}
+	opts := FetchOptions{UserIP: "5.6.7.8", NoCache: true}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.fetchWithCache(context.Background(), "counting", opts)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, client.calls, "concurrent identical requests should coalesce into one upstream call")
+}
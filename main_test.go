@@ -0,0 +1,38 @@
+package main
+
+import "context"
+
+// mockClient is a deterministic Client used by the test suite: it never
+// fails and always tags returned items with its own provider name.
+type mockClient struct {
+	source Provider
+}
+
+func (m *mockClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	items := make([]*ContentItem, count)
+	for i := range items {
+		items[i] = &ContentItem{Source: string(m.source), Title: query}
+	}
+	return items, nil
+}
+
+func providerRef(p Provider) *Provider { return &p }
+
+// DefaultConfig is the provider mix used throughout the test suite.
+var DefaultConfig = ContentMix{
+	{Type: "reddit", Fallback: providerRef("hackernews")},
+	{Type: "twitter", Fallback: providerRef("hackernews")},
+	{Type: "hackernews"},
+}
+
+var app = &App{
+	ContentClients: map[Provider]Client{
+		"reddit":     &mockClient{source: "reddit"},
+		"twitter":    &mockClient{source: "twitter"},
+		"hackernews": &mockClient{source: "hackernews"},
+	},
+	Config: DefaultConfig,
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errCircuitOpen is returned (and treated like any other provider error) when
+// a provider's circuit breaker is open and the call is short-circuited
+// straight to its fallback.
+var errCircuitOpen = errors.New("provider circuit open")
+
+// errShuttingDown is returned by submit(), and by callers of pool() when it
+// returns nil, once App.Shutdown has been called: no new work is accepted
+// after that point, and no new pools are spun up to service it.
+var errShuttingDown = errors.New("app is shutting down")
+
+// PoolConfig controls the size of the per-provider worker pools that service
+// content fetches. The zero value falls back to sensible defaults.
+type PoolConfig struct {
+	// WorkersPerProvider is how many long-lived goroutines each provider
+	// gets. Defaults to runtime.NumCPU() when zero or negative.
+	WorkersPerProvider int
+	// QueueSize bounds how many pending jobs a provider's queue may hold
+	// before submitting a job blocks, applying backpressure to callers.
+	QueueSize int
+	// ProviderTimeout bounds how long a single call to a provider's Client
+	// may run before it's cancelled. Defaults to 5s when zero or negative.
+	ProviderTimeout time.Duration
+}
+
+func (c PoolConfig) workersPerProvider() int {
+	if c.WorkersPerProvider > 0 {
+		return c.WorkersPerProvider
+	}
+	return runtime.NumCPU()
+}
+
+func (c PoolConfig) queueSize() int {
+	if c.QueueSize > 0 {
+		return c.QueueSize
+	}
+	return 64
+}
+
+func (c PoolConfig) providerTimeout() time.Duration {
+	if c.ProviderTimeout > 0 {
+		return c.ProviderTimeout
+	}
+	return 5 * time.Second
+}
+
+// poolJob is one unit of work handed to a provider's worker pool. idx is the
+// position in the App's ContentMix that requested the fetch, which is what
+// lets a worker find the right fallback provider on failure. ctx is the
+// originating request's context; the worker derives its own per-call
+// timeout from it. opts carries the cache key/bypass info for the request.
+// noFallback marks a job that is itself already servicing another job's
+// fallback, so its own failure doesn't trigger yet another fallback hop.
+type poolJob struct {
+	ctx        context.Context
+	idx        int
+	opts       FetchOptions
+	result     chan ContentResponse
+	noFallback bool
+}
+
+// PoolMetrics is a point-in-time snapshot of a provider pool's counters.
+type PoolMetrics struct {
+	Queued    int64 `json:"queued"`
+	Inflight  int64 `json:"inflight"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+}
+
+// providerPool is the bounded set of workers servicing a single provider.
+type providerPool struct {
+	app  *App
+	jobs chan poolJob
+	wg   sync.WaitGroup
+
+	queued    int64
+	inflight  int64
+	completed int64
+	failed    int64
+}
+
+func newProviderPool(a *App, provider Provider, workers, queueSize int) *providerPool {
+	p := &providerPool{app: a, jobs: make(chan poolJob, queueSize)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.run(a, provider)
+	}
+	return p
+}
+
+// run is the worker loop: it pulls jobs off the queue until it is closed,
+// fetching from provider (via fetchWithCache, which applies the cache,
+// singleflight coalescing, per-provider timeout and circuit breaker) and
+// falling back per the job's ContentMix entry on failure. The fallback fetch
+// is routed through the fallback provider's own pool (fetchFallback) rather
+// than run inline here, so it stays bound by the fallback's own
+// worker/queue limits instead of bypassing them.
+func (p *providerPool) run(a *App, provider Provider) {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		atomic.AddInt64(&p.queued, -1)
+		atomic.AddInt64(&p.inflight, 1)
+
+		content, err := a.fetchWithCache(job.ctx, provider, job.opts)
+		if err != nil {
+			atomic.AddInt64(&p.failed, 1)
+			if fallback := a.Config[job.idx].Fallback; !job.noFallback && fallback != nil {
+				content, err = a.fetchFallback(job, *fallback)
+			}
+		} else {
+			atomic.AddInt64(&p.completed, 1)
+		}
+
+		atomic.AddInt64(&p.inflight, -1)
+
+		select {
+		case job.result <- ContentResponse{Content: content, Error: err}:
+		case <-job.ctx.Done():
+		}
+	}
+}
+
+// fetchFallback submits job to fallback's own pool instead of calling
+// fetchWithCache directly, so providers configured as a common fallback
+// target still get their own bounded concurrency rather than being
+// swamped by every provider that falls back to them. noFallback is set on
+// the submitted job so a failure there doesn't chain into yet another hop.
+func (a *App) fetchFallback(job poolJob, fallback Provider) ([]*ContentItem, error) {
+	p := a.pool(fallback)
+	if p == nil {
+		return nil, errShuttingDown
+	}
+
+	result := make(chan ContentResponse, 1)
+	if err := p.submit(poolJob{ctx: job.ctx, idx: job.idx, opts: job.opts, result: result, noFallback: true}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-result:
+		return res.Content, res.Error
+	case <-job.ctx.Done():
+		return nil, job.ctx.Err()
+	}
+}
+
+// submit enqueues job, blocking while the queue is full. This is the
+// mechanism by which the pool applies backpressure to its caller. If the
+// job's context is done before a slot opens up, submit gives up and returns
+// the context's error instead of blocking forever on a caller that has
+// already gone away. It also rejects the job with errShuttingDown if
+// App.Shutdown has already been called.
+func (p *providerPool) submit(job poolJob) error {
+	p.app.poolsMu.Lock()
+	shuttingDown := p.app.shuttingDown
+	p.app.poolsMu.Unlock()
+	if shuttingDown {
+		return errShuttingDown
+	}
+
+	atomic.AddInt64(&p.queued, 1)
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-job.ctx.Done():
+		atomic.AddInt64(&p.queued, -1)
+		return job.ctx.Err()
+	}
+}
+
+func (p *providerPool) metrics() PoolMetrics {
+	return PoolMetrics{
+		Queued:    atomic.LoadInt64(&p.queued),
+		Inflight:  atomic.LoadInt64(&p.inflight),
+		Completed: atomic.LoadInt64(&p.completed),
+		Failed:    atomic.LoadInt64(&p.failed),
+	}
+}
+
+// pool returns the worker pool for provider, creating it on first use. It
+// returns nil once App.Shutdown has been called, instead of silently
+// spinning up a new, unmanaged pool that Shutdown will never drain.
+func (a *App) pool(provider Provider) *providerPool {
+	a.poolsMu.Lock()
+	defer a.poolsMu.Unlock()
+	if a.shuttingDown {
+		return nil
+	}
+	if a.providerPools == nil {
+		a.providerPools = map[Provider]*providerPool{}
+	}
+	if p, ok := a.providerPools[provider]; ok {
+		return p
+	}
+	p := newProviderPool(a, provider, a.PoolConfig.workersPerProvider(), a.PoolConfig.queueSize())
+	a.providerPools[provider] = p
+	return p
+}
+
+// Shutdown stops accepting new work on every provider pool and waits for
+// in-flight jobs to drain, or for ctx to be done, whichever comes first.
+// After Shutdown is called, pool() and submit() reject further work instead
+// of creating new pools or enqueueing onto pools nothing will ever drain.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.poolsMu.Lock()
+	a.shuttingDown = true
+	pools := a.providerPools
+	a.providerPools = nil
+	a.poolsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		for _, p := range pools {
+			close(p.jobs)
+			p.wg.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PoolMetricsHandler serves a JSON snapshot of every provider pool's
+// counters, keyed by provider. Intended to be mounted on an internal-only
+// path, e.g. "/internal/pools".
+func (a *App) PoolMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	a.poolsMu.Lock()
+	snapshot := make(map[Provider]PoolMetrics, len(a.providerPools))
+	for provider, p := range a.providerPools {
+		snapshot[provider] = p.metrics()
+	}
+	a.poolsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
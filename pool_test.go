@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// alwaysFailClient is a Client whose GetContent always fails immediately.
+type alwaysFailClient struct{}
+
+func (alwaysFailClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	return nil, errors.New("boom")
+}
+
+// blockingClient is a Client whose GetContent blocks until release is
+// closed, signalling on started the first time it's entered. Used to
+// deterministically saturate a provider pool's worker(s).
+type blockingClient struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	select {
+	case c.started <- struct{}{}:
+	default:
+	}
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []*ContentItem{{Source: "blocking", Title: query}}, nil
+}
+
+func newTestPoolApp(client Client) *App {
+	return &App{
+		ContentClients: map[Provider]Client{"blocking": client},
+		Config:         ContentMix{{Type: "blocking"}},
+		PoolConfig:     PoolConfig{WorkersPerProvider: 1, QueueSize: 1},
+	}
+}
+
+// TestSubmitBlocksWhileQueueFull asserts submit blocks (applying
+// backpressure) while a provider's single worker and single queue slot are
+// both occupied, and unblocks once a slot frees up.
+func TestSubmitBlocksWhileQueueFull(t *testing.T) {
+	client := &blockingClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	a := newTestPoolApp(client)
+	p := a.pool("blocking")
+
+	// occupy the single worker
+	ch1 := make(chan ContentResponse, 1)
+	assert.NoError(t, p.submit(poolJob{ctx: context.Background(), idx: 0, result: ch1}))
+	<-client.started
+
+	// occupy the single queue slot
+	ch2 := make(chan ContentResponse, 1)
+	assert.NoError(t, p.submit(poolJob{ctx: context.Background(), idx: 0, result: ch2}))
+
+	submitted := make(chan error, 1)
+	ch3 := make(chan ContentResponse, 1)
+	go func() {
+		submitted <- p.submit(poolJob{ctx: context.Background(), idx: 0, result: ch3})
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatal("submit returned before a slot freed up")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.release)
+	select {
+	case err := <-submitted:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("submit never unblocked after a slot freed up")
+	}
+	<-ch1
+	<-ch2
+	<-ch3
+}
+
+// TestSubmitCanceledContext asserts submit gives up and returns ctx.Err()
+// once ctx is done, instead of blocking forever on a full queue.
+func TestSubmitCanceledContext(t *testing.T) {
+	client := &blockingClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(client.release)
+	a := newTestPoolApp(client)
+	p := a.pool("blocking")
+
+	// occupy the worker and the queue slot so the next submit must block
+	assert.NoError(t, p.submit(poolJob{ctx: context.Background(), idx: 0, result: make(chan ContentResponse, 1)}))
+	<-client.started
+	assert.NoError(t, p.submit(poolJob{ctx: context.Background(), idx: 0, result: make(chan ContentResponse, 1)}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.submit(poolJob{ctx: ctx, idx: 0, result: make(chan ContentResponse, 1)})
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("submit did not return promptly for an already-canceled context")
+	}
+}
+
+// TestPoolMetrics asserts queued/inflight/completed/failed counters track a
+// job through its lifecycle.
+func TestPoolMetrics(t *testing.T) {
+	a := &App{
+		ContentClients: map[Provider]Client{"reddit": &mockClient{source: "reddit"}},
+		Config:         ContentMix{{Type: "reddit"}},
+		PoolConfig:     PoolConfig{WorkersPerProvider: 1, QueueSize: 1},
+	}
+	p := a.pool("reddit")
+
+	ch := make(chan ContentResponse, 1)
+	assert.NoError(t, p.submit(poolJob{ctx: context.Background(), idx: 0, result: ch}))
+	<-ch
+
+	assert.Eventually(t, func() bool {
+		m := p.metrics()
+		return m.Completed == 1 && m.Failed == 0 && m.Inflight == 0
+	}, time.Second, time.Millisecond)
+}
+
+// TestShutdownWaitsForInflight asserts Shutdown blocks until in-flight jobs
+// drain, then returns nil.
+func TestShutdownWaitsForInflight(t *testing.T) {
+	client := &blockingClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	a := newTestPoolApp(client)
+	ch := make(chan ContentResponse, 1)
+	assert.NoError(t, a.pool("blocking").submit(poolJob{ctx: context.Background(), idx: 0, result: ch}))
+	<-client.started
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- a.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before the in-flight job finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.release)
+	select {
+	case err := <-shutdownDone:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown never returned after the in-flight job finished")
+	}
+	<-ch
+}
+
+// TestShutdownDeadline asserts Shutdown returns ctx.Err() if ctx is done
+// before in-flight jobs drain.
+func TestShutdownDeadline(t *testing.T) {
+	client := &blockingClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(client.release)
+	a := newTestPoolApp(client)
+	assert.NoError(t, a.pool("blocking").submit(poolJob{ctx: context.Background(), idx: 0, result: make(chan ContentResponse, 1)}))
+	<-client.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, a.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+// TestPoolNilAfterShutdown asserts pool() stops handing out (or creating)
+// pools once Shutdown has been called, instead of silently spinning up a
+// new, unmanaged pool that nothing will ever drain.
+func TestPoolNilAfterShutdown(t *testing.T) {
+	a := &App{
+		ContentClients: map[Provider]Client{"reddit": &mockClient{source: "reddit"}},
+		Config:         ContentMix{{Type: "reddit"}},
+	}
+	assert.NoError(t, a.Shutdown(context.Background()))
+
+	assert.Nil(t, a.pool("reddit"), "pool() should return nil once shutting down")
+	assert.Nil(t, a.pool("never-seen-before"), "pool() should not create a new pool once shutting down")
+
+	err := a.getContent(context.Background(), 0, FetchOptions{}, make(chan ContentResponse, 1))
+	assert.ErrorIs(t, err, errShuttingDown)
+}
+
+// TestFallbackRoutesThroughOwnPool asserts a fallback fetch is bound by the
+// fallback provider's own worker/queue limits rather than running inline
+// on the primary provider's worker and bypassing them. Three distinct
+// primaries all fall back to the same provider, which has capacity for only
+// one in-flight plus one queued job; the third fallback must block on that
+// bound instead of completing immediately.
+func TestFallbackRoutesThroughOwnPool(t *testing.T) {
+	fbName := Provider("fb")
+	fb := &blockingClient{started: make(chan struct{}, 3), release: make(chan struct{})}
+
+	a := &App{
+		ContentClients: map[Provider]Client{
+			"p1": alwaysFailClient{},
+			"p2": alwaysFailClient{},
+			"p3": alwaysFailClient{},
+			"fb": fb,
+		},
+		Config: ContentMix{
+			{Type: "p1", Fallback: &fbName},
+			{Type: "p2", Fallback: &fbName},
+			{Type: "p3", Fallback: &fbName},
+		},
+		PoolConfig: PoolConfig{WorkersPerProvider: 1, QueueSize: 1},
+	}
+
+	results := make([]chan ContentResponse, 3)
+	for i, provider := range []Provider{"p1", "p2", "p3"} {
+		results[i] = make(chan ContentResponse, 1)
+		assert.NoError(t, a.pool(provider).submit(poolJob{ctx: context.Background(), idx: i, result: results[i]}))
+	}
+
+	select {
+	case <-results[2]:
+		t.Fatal("third fallback completed without waiting on fb's own pool bound")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(fb.release)
+	for _, ch := range results {
+		select {
+		case res := <-ch:
+			assert.NoError(t, res.Error)
+			assert.Len(t, res.Content, 1)
+		case <-time.After(time.Second):
+			t.Fatal("fallback result never arrived after release")
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "context"
+
+// Provider identifies a content source that the app can fetch from, e.g. a
+// particular social feed or news API.
+type Provider string
+
+// ContentItem is a single piece of content returned by a Client.
+type ContentItem struct {
+	Source string
+	Title  string
+	Body   string
+}
+
+// Client fetches content from a single provider. Implementations must
+// respect ctx cancellation/deadline and return promptly once it's done.
+type Client interface {
+	GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error)
+}
+
+// ProviderConfig describes one provider in the content mix: which provider to
+// query, and which provider (if any) to fall back to when it fails.
+type ProviderConfig struct {
+	Type     Provider
+	Fallback *Provider
+}
+
+// ContentMix is an ordered list of providers to pull content from. Providers
+// are visited in order, wrapping back to the start once the end is reached.
+type ContentMix []ProviderConfig
+
+// providerAt formally defines the position -> provider mapping that
+// fetchRange's batching loop implements: the i-th item (0-indexed) of a
+// fetch that started at offset comes from the provider at position
+// (offset+i) mod len(m), cycling offset, offset+1, ..., len(m)-1, 0, 1, ...
+//
+// This is the "happy path" mapping. When a provider fails, fetchRange fills
+// that same position with its Fallback's content instead (not the next
+// provider in the cycle); if the fallback also fails, the whole fetch stops
+// there rather than skipping ahead to the next position.
+func (m ContentMix) providerAt(offset, i int) Provider {
+	return m[(offset+i)%len(m)].Type
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderAt(t *testing.T) {
+	n := len(DefaultConfig)
+
+	cases := []struct {
+		name   string
+		offset int
+		i      int
+		want   Provider
+	}{
+		{"offset 0, first item", 0, 0, DefaultConfig[0].Type},
+		{"offset 0, wraps past the end", 0, n, DefaultConfig[0].Type},
+		{"non-zero offset, first item", 1, 0, DefaultConfig[1].Type},
+		{"non-zero offset, wraps past the end", 1, n, DefaultConfig[1].Type},
+		{"offset itself wraps", n + 2, 0, DefaultConfig[2].Type},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, DefaultConfig.providerAt(tc.offset, tc.i))
+		})
+	}
+}
+
+// requestContent runs a GET request against app and decodes its JSON body,
+// returning ok=false instead of failing the test so it's safe to call from
+// inside a testing/quick property function.
+func requestContent(offset, count int) (content []*ContentItem, ok bool) {
+	r := SetOffsetCountRequest(offset, count)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	if w.Code != 200 {
+		return nil, false
+	}
+	if err := json.NewDecoder(w.Body).Decode(&content); err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// TestOffsetOrderProperty asserts, for random (offset, count) pairs, that the
+// returned sequence matches providerAt exactly and its length is count. The
+// test suite's mock clients never fail, so nothing is ever truncated early.
+func TestOffsetOrderProperty(t *testing.T) {
+	property := func(rawOffset, rawCount uint16) bool {
+		offset := int(rawOffset) % 1000
+		count := int(rawCount) % 50
+
+		content, ok := requestContent(offset, count)
+		if !ok || len(content) != count {
+			return false
+		}
+		for i, item := range content {
+			if DefaultConfig.providerAt(offset, i) != Provider(item.Source) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
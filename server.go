@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // App represents the server's internal state.
@@ -13,6 +18,51 @@ import (
 type App struct {
 	ContentClients map[Provider]Client
 	Config         ContentMix
+
+	// PoolConfig sizes the per-provider worker pools that service fetches.
+	// See pool.go.
+	PoolConfig PoolConfig
+
+	// MaxBatchItems and MaxBatchTotalCount bound POST /batch requests (see
+	// batch.go). Zero means DefaultMaxBatchItems / DefaultMaxBatchTotalCount.
+	MaxBatchItems      int
+	MaxBatchTotalCount int
+
+	poolsMu       sync.Mutex
+	providerPools map[Provider]*providerPool
+	// shuttingDown is set by Shutdown, under poolsMu, so that pool() and
+	// submit() reject post-Shutdown work instead of silently spawning new,
+	// unmanaged pools that nothing will ever drain.
+	shuttingDown bool
+
+	statsMu    sync.Mutex
+	statsByKey map[Provider]*providerStats
+
+	// Cache and CacheTTL configure the response cache (see cache.go). Cache
+	// defaults to an LRUCache and CacheTTL to DefaultCacheTTL when unset.
+	Cache     Cache
+	CacheTTL  time.Duration
+	cacheOnce sync.Once
+
+	cacheHits   int64
+	cacheMisses int64
+	sfGroup     singleflight.Group
+}
+
+// providerStats returns the circuit-breaker stats for provider, creating
+// them on first use.
+func (a *App) providerStats(provider Provider) *providerStats {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if a.statsByKey == nil {
+		a.statsByKey = map[Provider]*providerStats{}
+	}
+	if s, ok := a.statsByKey[provider]; ok {
+		return s
+	}
+	s := &providerStats{}
+	a.statsByKey[provider] = s
+	return s
 }
 
 // ContentResponse represents type used to wrap a provider's content result and error into a single struct
@@ -34,23 +84,72 @@ func getQueryParameters(r *http.Request) (count, offset int) {
 	return
 }
 
-// channelToResponseWriter : marshals slice of ContentItem structs and writes them to http writer
-func channelToResponseWriter(val []*ContentItem, w *http.ResponseWriter) {
-	marsh, _ := json.Marshal(val[0])
-	(*w).Write(marsh)
+// arrayWriter frames a stream of values as a single JSON array written
+// incrementally to w. It does not write anything until the first item (or
+// writeArrayEnd) is written, so callers can still fall back to a proper HTTP
+// error as long as nothing has been flushed yet (see opened).
+type arrayWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	opened  bool // true once "[" has been flushed to w
+	hasItem bool // true once at least one item has been written
 }
 
-// ServeHTTP : main HTTP Handler for GET requests on server
-// Concurrently executes getting content from providers, in manner detailed in README.md
-func (a App) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	w.Header().Set("Status", "200")
-	w.Header().Set("Content-Type", "application/json")
-	log.Printf("%s %s", req.Method, req.URL.String())
-	count, offset := getQueryParameters(req)
+func newArrayWriter(w http.ResponseWriter) *arrayWriter {
+	return &arrayWriter{w: w, enc: json.NewEncoder(w)}
+}
 
-	// write first characters of response, which is going to be an array
-	w.Write([]byte("["))
-	defer w.Write([]byte("]"))
+// writeArrayStart writes the opening "[" of the array, if it hasn't been
+// written already.
+func (a *arrayWriter) writeArrayStart() error {
+	if a.opened {
+		return nil
+	}
+	a.opened = true
+	_, err := a.w.Write([]byte("["))
+	return err
+}
+
+// writeArrayItem encodes v as the next element of the array, writing the
+// opening "[" and a separating "," first as needed.
+func (a *arrayWriter) writeArrayItem(v interface{}) error {
+	if err := a.writeArrayStart(); err != nil {
+		return err
+	}
+	if a.hasItem {
+		if _, err := a.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	a.hasItem = true
+	return a.enc.Encode(v)
+}
+
+// writeArrayEnd writes the closing "]" of the array, opening it first if no
+// items were ever written (i.e. an empty array).
+func (a *arrayWriter) writeArrayEnd() error {
+	if err := a.writeArrayStart(); err != nil {
+		return err
+	}
+	_, err := a.w.Write([]byte("]"))
+	return err
+}
+
+// fetchRange fetches count items from a.Config starting at offset, invoking
+// emit for each item in order as it arrives. It stops and returns emit's
+// error if emit fails, or a provider's error if one is fatal (no fallback
+// configured, or the fallback failed too), or ctx.Err() if ctx is done before
+// count items are fetched.
+// Both ServeHTTP and the /batch handler (see batch.go) share this loop.
+func (a *App) fetchRange(ctx context.Context, offset, count int, opts FetchOptions, emit func(*ContentItem) error) error {
+	if len(a.Config) == 0 {
+		return nil
+	}
+	// normalize offset into [0, len(a.Config)) first: the batch loop below only
+	// wraps offset back to 0 *after* its first pass, so an offset that's
+	// already >= len(a.Config) would otherwise skip straight to that reset and
+	// silently behave as offset=0 instead of wrapping per ContentMix.providerAt
+	offset %= len(a.Config)
 
 	// slice of channels, one for each content provider in configuration
 	// using this, we can concurrently get content from each provider and get the data back in a specific order
@@ -58,15 +157,19 @@ func (a App) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	for i := 0; i < len(a.Config); i++ {
 		chans[i] = make(chan ContentResponse)
 	}
+	// if the loop is stopped early (error or ctx done), some workers may still
+	// be about to send on these channels; cancelling ctx makes them give up on
+	// that send instead. The channels are left open, since a send on a closed
+	// channel would panic, and that data never reaches the response and is
+	// discarded by garbage collection
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// holds total number of content bits taken from providers
 	totalCount := 0
 	// boolean parameter that is used to stop content fetching if number of desired entries has already been fetched
 	done := false
 	i := 0
-	// boolean parameter that determines if the current loop corresponds to the first batch of content
-	// is mostly used to generate the JSON byte string correctly
-	onFirstWrite := true
 
 	// Loop which concurrently executes one content request per provider in batches, until desired articles count is met
 ContentBatchLoop:
@@ -89,9 +192,13 @@ ContentBatchLoop:
 			}
 			totalCount++
 
-			// concurrently get content from provider
-			// note that each provider of the app has it's own assigned channel (chans[i])
-			go a.getContent(i, chans[i])
+			// hand the fetch off to the provider's bounded worker pool instead of
+			// spawning a goroutine per item; submit blocks only if that provider's
+			// queue is full, which is the pool's backpressure mechanism, but gives
+			// up and returns an error if ctx is done before a slot opens up
+			if err := a.getContent(ctx, i, opts, chans[i]); err != nil {
+				return err
+			}
 		}
 
 		// edge case. TODO: find way to not need this anymore
@@ -104,59 +211,74 @@ ContentBatchLoop:
 		// j < i syntax makes sure that we do not do more RECEIVE operations that SEND ones executed earlier
 		// A case where that might happen is when the break is called. In that case, i won't go all the way to len(a.Config)
 		for j := offset; j < i; j++ {
-			// get value from provider's channel
-			val := <-chans[j]
+			// get value from provider's channel, but give up immediately if the
+			// caller has gone away so we don't wait on a job that nobody needs
+			var val ContentResponse
+			select {
+			case val = <-chans[j]:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 			if val.Error != nil {
 				// check if there is an error attached to the data on the channel, stop operations
-				break ContentBatchLoop
+				return val.Error
 			}
 
-			// we check if we are on the first batch of data, case when we don't want to add "," initially
-			// for all other cases, we can add ","
-			if !onFirstWrite {
-				w.Write([]byte(","))
+			// emit every item in the batch, not just the first
+			for _, item := range val.Content {
+				if err := emit(item); err != nil {
+					return err
+				}
 			}
-			onFirstWrite = false
-
-			// if value does not have an error attached, we can write it to the http writer
-			channelToResponseWriter(val.Content, &w)
-
 		}
 
 		// after the first pass, we can loop another batch of provider requests starting with the first one
 		offset = 0
 	}
 
-	// close all channels for each config provider
-	// at this point, if there was an error and the Mainloop was stopped forcefully, we may still have data on some of these
-	// Although, that data does not end up in the response and is discarded by garbage collection
-	for i := 0; i < len(a.Config); i++ {
-		close(chans[i])
+	return nil
+}
+
+// ServeHTTP : main HTTP Handler for GET requests on server
+// Concurrently executes getting content from providers, in manner detailed in README.md
+func (a *App) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	log.Printf("%s %s", req.Method, req.URL.String())
+	count, offset := getQueryParameters(req)
+
+	// aw frames the response as a JSON array but doesn't write anything until
+	// the first item (or the final, possibly-empty close) is written, so a
+	// fatal error before that point can still become a proper HTTP error
+	// instead of malformed, already-200'd output.
+	aw := newArrayWriter(w)
+
+	fatalErr := a.fetchRange(req.Context(), offset, count, fetchOptionsFor(req), func(item *ContentItem) error {
+		return aw.writeArrayItem(item)
+	})
+
+	if fatalErr != nil && !aw.opened {
+		// nothing has been flushed yet, so we can still report a real HTTP error
+		http.Error(w, fatalErr.Error(), http.StatusBadGateway)
+		return
+	}
+	if err := aw.writeArrayEnd(); err != nil {
+		log.Printf("error closing response stream: %v", err)
 	}
 
 	fmt.Println("\nDone")
-	return
 }
 
-// getContent gets content from one of the application's providers
-func (a App) getContent(idx int, ch chan ContentResponse) {
-	// first attempt to call main content client
-	// it is accessed by going through the providers-clients map
-	// and finding the corresponding client for a specific provider, which gives access to it's GetContent method
-	content, err := a.ContentClients[a.Config[idx].Type].GetContent("Test", 1)
-	if err != nil {
-		fallbackContent, fallbackErr := a.ContentClients[*a.Config[idx].Fallback].GetContent("Test", 1)
-		// send content and potential error to channel
-		ch <- ContentResponse{
-			Content: fallbackContent,
-			Error:   fallbackErr,
-		}
-		return
-	}
-	// send content and potential error to channel
-	ch <- ContentResponse{
-		Content: content,
-		Error:   nil,
+// getContent gets content from one of the application's providers by
+// submitting a job to that provider's worker pool (see pool.go); the actual
+// fetch, including fallback on error, happens in the pool's worker goroutine.
+// ctx is the originating request's context, so a client disconnect cancels
+// the in-flight provider call. It returns ctx's error if the job could not
+// even be submitted because the pool's queue stayed full until ctx was done,
+// or errShuttingDown if App.Shutdown has already been called.
+func (a *App) getContent(ctx context.Context, idx int, opts FetchOptions, ch chan ContentResponse) error {
+	p := a.pool(a.Config[idx].Type)
+	if p == nil {
+		return errShuttingDown
 	}
-	return
+	return p.submit(poolJob{ctx: ctx, idx: idx, opts: opts, result: ch})
 }
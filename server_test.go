@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// multiItemClient always returns n items per call, tagged with their index,
+// regardless of the requested count. Used to exercise the "for _, item :=
+// range val.Content" loop in fetchRange, which every other test client
+// leaves dead by always returning exactly one item per call.
+type multiItemClient struct {
+	n int
+}
+
+func (c multiItemClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	items := make([]*ContentItem, c.n)
+	for i := range items {
+		items[i] = &ContentItem{Source: "multi", Title: fmt.Sprintf("%s-%d", query, i)}
+	}
+	return items, nil
+}
+
+// failingClient always fails with err.
+type failingClient struct {
+	err error
+}
+
+func (c failingClient) GetContent(ctx context.Context, query string, count int) ([]*ContentItem, error) {
+	return nil, c.err
+}
+
+// TestServeHTTPEmitsAllItemsFromOneProviderCall asserts every item a single
+// provider call returns is emitted, in order, not just the first.
+func TestServeHTTPEmitsAllItemsFromOneProviderCall(t *testing.T) {
+	a := &App{
+		ContentClients: map[Provider]Client{"multi": multiItemClient{n: 3}},
+		Config:         ContentMix{{Type: "multi"}},
+	}
+
+	r := httptest.NewRequest("GET", "/?offset=0&count=1", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	assert.Equal(t, 200, w.Code)
+	var content []*ContentItem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&content))
+
+	assert.Len(t, content, 3)
+	for i, item := range content {
+		assert.Equal(t, fmt.Sprintf("Test-%d", i), item.Title)
+	}
+}
+
+// TestServeHTTPFatalErrorBeforeAnyFlush asserts a fatal provider error that
+// happens before any item has been written still becomes a proper HTTP
+// error, instead of an already-200'd, malformed response.
+func TestServeHTTPFatalErrorBeforeAnyFlush(t *testing.T) {
+	boom := errors.New("boom")
+	a := &App{
+		ContentClients: map[Provider]Client{"broken": failingClient{err: boom}},
+		Config:         ContentMix{{Type: "broken"}},
+	}
+
+	r := httptest.NewRequest("GET", "/?offset=0&count=1", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	assert.Equal(t, 502, w.Code)
+	assert.Contains(t, w.Body.String(), boom.Error())
+}
+
+// TestServeHTTPFatalErrorAfterFlush asserts a fatal provider error that
+// happens after the first item was already flushed still closes out the
+// array cleanly (the client sees a short, valid array rather than a
+// mid-stream HTTP error), since the 200 and first bytes are already sent.
+func TestServeHTTPFatalErrorAfterFlush(t *testing.T) {
+	boom := errors.New("boom")
+	a := &App{
+		ContentClients: map[Provider]Client{
+			"ok":     &mockClient{source: "ok"},
+			"broken": failingClient{err: boom},
+		},
+		Config: ContentMix{{Type: "ok"}, {Type: "broken"}},
+	}
+
+	r := httptest.NewRequest("GET", "/?offset=0&count=2", nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	assert.Equal(t, 200, w.Code)
+	var content []*ContentItem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&content))
+	assert.Len(t, content, 1)
+	assert.Equal(t, "ok", content[0].Source)
+}
+
+// TestArrayWriterCommaSeparation asserts multiple items are joined by commas
+// into a single valid JSON array.
+func TestArrayWriterCommaSeparation(t *testing.T) {
+	w := httptest.NewRecorder()
+	aw := newArrayWriter(w)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, aw.writeArrayItem(&ContentItem{Source: fmt.Sprintf("item-%d", i)}))
+	}
+	assert.NoError(t, aw.writeArrayEnd())
+
+	var content []*ContentItem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&content))
+	assert.Len(t, content, 3)
+	for i, item := range content {
+		assert.Equal(t, fmt.Sprintf("item-%d", i), item.Source)
+	}
+}
+
+// TestArrayWriterEmptyArray asserts writeArrayEnd alone produces a valid,
+// empty JSON array.
+func TestArrayWriterEmptyArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	aw := newArrayWriter(w)
+	assert.NoError(t, aw.writeArrayEnd())
+
+	var content []*ContentItem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&content))
+	assert.Len(t, content, 0)
+}